@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/go-github/v52/github"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagOwner    string
+	flagRepo     string
+	flagCreator  string
+	flagInterval time.Duration
+	flagConfig   string
+	flagStateDB  string
+	flagCISource string
+)
+
+// applyCISourceFlag 解析 --ci-source 并设置 main.go 的 ciSourceMode 全局开关，
+// 供 monitor/serve 这些会触发 CI 检查的子命令在启动时调用
+func applyCISourceFlag() error {
+	mode, err := parseCISource(flagCISource)
+	if err != nil {
+		return err
+	}
+	ciSourceMode = mode
+	return nil
+}
+
+// initStore 打开 --state-db 指定的状态后端，不指定则回退到内存实现
+func initStore() {
+	s, err := NewStateStore(flagStateDB)
+	if err != nil {
+		log.Fatalf("Error opening state store %q: %v", flagStateDB, err)
+	}
+	store = s
+}
+
+func newRootCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "git_ci_alert",
+		Short: "Watch GitHub pull requests and alert on CI failures, merges, staleness and pending CI",
+	}
+
+	rootCmd.PersistentFlags().StringVar(&flagOwner, "owner", "PaddlePaddle", "repository owner")
+	rootCmd.PersistentFlags().StringVar(&flagRepo, "repo", "Paddle", "repository name")
+	rootCmd.PersistentFlags().StringVar(&flagCreator, "creator", "GoldenStain", "only watch PRs created by this user")
+	rootCmd.PersistentFlags().DurationVar(&flagInterval, "interval", 360*time.Second, "polling interval")
+	rootCmd.PersistentFlags().StringVar(&flagStateDB, "state-db", "", "path to a BoltDB file for persisting PR/CI state across restarts (default: in-memory, not persisted)")
+	rootCmd.PersistentFlags().StringVar(&flagCISource, "ci-source", "both", "which CI data source to check: statuses, checks, or both")
+
+	rootCmd.AddCommand(newMonitorCmd())
+	rootCmd.AddCommand(newNotifyStaleCmd())
+	rootCmd.AddCommand(newNotifyPendingCICmd())
+	rootCmd.AddCommand(newServeCmd())
+
+	return rootCmd
+}
+
+func newMonitorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "monitor",
+		Short: "Poll PRs and alert on CI failures and merges (and optionally staleness/pending CI)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyCISourceFlag(); err != nil {
+				return err
+			}
+
+			initClient()
+			notifier = NewMultiNotifier(buildNotifiers()...)
+			initStore()
+			defer store.Close()
+
+			if flagConfig != "" {
+				return RunWithHotReload(flagConfig)
+			}
+
+			monitorPRs(flagOwner, flagRepo, flagCreator)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&flagConfig, "config", "", "path to a YAML config file listing multiple watch targets (overrides --owner/--repo/--creator)")
+	cmd.Flags().BoolVar(&staleCheckEnabled, "stale-check", true, "also alert on PRs that have gone stale (a Target's stale_check overrides this)")
+	cmd.Flags().BoolVar(&pendingCICheckEnabled, "pending-ci-check", true, "also alert on PRs whose CI never triggered (a Target's pending_ci_check overrides this)")
+	return cmd
+}
+
+func newNotifyStaleCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "notify-stale",
+		Short: "Poll PRs and alert on ones that have gone stale",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initClient()
+			notifier = NewMultiNotifier(buildNotifiers()...)
+			pollPRs(flagOwner, flagRepo, flagCreator, func(owner, repo string, pr *github.PullRequest) {
+				checkStaleForPR(owner, repo, pr)
+			})
+			return nil
+		},
+	}
+}
+
+func newServeCmd() *cobra.Command {
+	var addr string
+	var webhookSecret string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Receive GitHub webhook events (pull_request/status/check_run/check_suite) instead of polling",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyCISourceFlag(); err != nil {
+				return err
+			}
+
+			initClient()
+			notifier = NewMultiNotifier(buildNotifiers()...)
+			initStore()
+			defer store.Close()
+
+			if webhookSecret == "" {
+				webhookSecret = os.Getenv("GITHUB_WEBHOOK_SECRET")
+			}
+			if webhookSecret == "" {
+				log.Fatal("--webhook-secret or GITHUB_WEBHOOK_SECRET is required")
+			}
+
+			cfg := &Config{Targets: []Target{{Owner: flagOwner, Repo: flagRepo, Creators: []string{flagCreator}, PollInterval: flagInterval}}}
+			if flagConfig != "" {
+				loaded, err := LoadConfig(flagConfig)
+				if err != nil {
+					return err
+				}
+				cfg = loaded
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+			go func() {
+				<-sigCh
+				cancel()
+			}()
+
+			return ServeWebhooks(ctx, addr, webhookSecret, cfg)
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", ":8080", "address to listen for webhook deliveries on")
+	cmd.Flags().StringVar(&webhookSecret, "webhook-secret", "", "shared secret used to validate X-Hub-Signature-256 (falls back to GITHUB_WEBHOOK_SECRET)")
+	cmd.Flags().StringVar(&flagConfig, "config", "", "path to a YAML config file listing multiple watch targets (overrides --owner/--repo/--creator)")
+	return cmd
+}
+
+func newNotifyPendingCICmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "notify-pending-ci",
+		Short: "Poll PRs and alert on ones whose CI never triggered",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initClient()
+			notifier = NewMultiNotifier(buildNotifiers()...)
+			pollPRs(flagOwner, flagRepo, flagCreator, func(owner, repo string, pr *github.PullRequest) {
+				checkPendingCIForPR(owner, repo, pr)
+			})
+			return nil
+		},
+	}
+}
+
+// pollPRs 周期性地获取 PR 列表并对每个 PR 执行给定的检查，供各个单一用途的子命令复用
+func pollPRs(owner, repo, creator string, check func(owner, repo string, pr *github.PullRequest)) {
+	for {
+		prs, err := getPRs(owner, repo, creator)
+		if err != nil {
+			log.Printf("Error fetching PRs: %v", err)
+		} else {
+			for _, pr := range prs {
+				check(owner, repo, pr)
+			}
+		}
+		time.Sleep(flagInterval)
+	}
+}
+
+func Execute() {
+	if err := newRootCmd().Execute(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultPollInterval 是 Target 未指定 poll_interval 时使用的默认轮询间隔，
+// 与老版本 monitorPRs 的 360 秒循环保持一致
+const defaultPollInterval = 360 * time.Second
+
+// Target 描述一个需要监控的仓库：谁的 PR、多久轮询一次、哪些 CI 是必须的/可以忽略的，
+// 以及告警要走哪些 Notifier
+type Target struct {
+	Owner        string        `yaml:"owner"`
+	Repo         string        `yaml:"repo"`
+	Creators     []string      `yaml:"creators"` // 支持 "*" 表示任意作者
+	PollInterval time.Duration `yaml:"poll_interval"`
+	RequiredCIs  []string      `yaml:"required_cis"` // glob，为空表示不限制
+	IgnoredCIs   []string      `yaml:"ignored_cis"`  // glob
+	Notifiers    []string      `yaml:"notifiers"`    // 为空则使用全局 NOTIFIERS 配置
+	Webhook      bool          `yaml:"webhook"`      // true 表示这个仓库已经配置了 webhook，serve 不需要再轮询它
+
+	// StaleCheck/PendingCICheck 控制这个 Target 是否参与 stale/pending-CI 检查，
+	// nil 表示沿用全局的 --stale-check/--pending-ci-check 开关
+	StaleCheck     *bool `yaml:"stale_check"`
+	PendingCICheck *bool `yaml:"pending_ci_check"`
+}
+
+// Config 是配置文件的根节点，包含所有需要监控的 Target
+type Config struct {
+	Targets []Target `yaml:"targets"`
+}
+
+// LoadConfig 从 YAML 文件读取多仓库监控配置，并补全每个 Target 缺省的字段
+func LoadConfig(configPath string) (*Config, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	for i := range cfg.Targets {
+		cfg.Targets[i].applyDefaults()
+	}
+
+	return &cfg, nil
+}
+
+func (t *Target) applyDefaults() {
+	if t.PollInterval <= 0 {
+		t.PollInterval = defaultPollInterval
+	}
+	if len(t.Creators) == 0 {
+		t.Creators = []string{"*"}
+	}
+}
+
+// staleCheckEnabled 判断这个 Target 是否要做 stale 检查，未配置时回退到全局开关
+func (t *Target) staleCheckEnabled() bool {
+	if t.StaleCheck != nil {
+		return *t.StaleCheck
+	}
+	return staleCheckEnabled
+}
+
+// pendingCICheckEnabled 判断这个 Target 是否要做 pending CI 检查，未配置时回退到全局开关
+func (t *Target) pendingCICheckEnabled() bool {
+	if t.PendingCICheck != nil {
+		return *t.PendingCICheck
+	}
+	return pendingCICheckEnabled
+}
+
+// matchesCreator 判断某个 PR 作者是否属于这个 Target 关心的作者列表，"*" 匹配任意作者
+func (t *Target) matchesCreator(login string) bool {
+	for _, creator := range t.Creators {
+		if creator == "*" || creator == login {
+			return true
+		}
+	}
+	return false
+}
+
+// isCIIgnored 判断某个 CI（context 名或 check 名）在这个 Target 下是否应当被忽略：
+// 命中 ignored_cis 直接忽略；配置了 required_cis 时，没命中就视为非必需
+func (t *Target) isCIIgnored(ciName string) bool {
+	if matchesAnyGlob(t.IgnoredCIs, ciName) {
+		return true
+	}
+	if len(t.RequiredCIs) > 0 && !matchesAnyGlob(t.RequiredCIs, ciName) {
+		return true
+	}
+	return false
+}
+
+func matchesAnyGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
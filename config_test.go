@@ -0,0 +1,84 @@
+package main
+
+import "testing"
+
+func TestMatchesAnyGlob(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		ciName   string
+		want     bool
+	}{
+		{"no patterns never match", nil, "ci/build", false},
+		{"exact match", []string{"ci/build"}, "ci/build", true},
+		{"glob match", []string{"ci/*"}, "ci/build", true},
+		{"glob no match", []string{"ci/*"}, "deploy/build", false},
+		{"second pattern matches", []string{"deploy/*", "ci/*"}, "ci/lint", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesAnyGlob(tt.patterns, tt.ciName); got != tt.want {
+				t.Errorf("matchesAnyGlob(%v, %q) = %v, want %v", tt.patterns, tt.ciName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetApplyDefaults(t *testing.T) {
+	target := Target{}
+	target.applyDefaults()
+
+	if target.PollInterval != defaultPollInterval {
+		t.Errorf("PollInterval = %v, want default %v", target.PollInterval, defaultPollInterval)
+	}
+	if len(target.Creators) != 1 || target.Creators[0] != "*" {
+		t.Errorf("Creators = %v, want [\"*\"]", target.Creators)
+	}
+}
+
+func TestTargetApplyDefaultsKeepsExplicitValues(t *testing.T) {
+	target := Target{PollInterval: 42, Creators: []string{"alice"}}
+	target.applyDefaults()
+
+	if target.PollInterval != 42 {
+		t.Errorf("PollInterval = %v, want unchanged 42", target.PollInterval)
+	}
+	if len(target.Creators) != 1 || target.Creators[0] != "alice" {
+		t.Errorf("Creators = %v, want unchanged [\"alice\"]", target.Creators)
+	}
+}
+
+func TestTargetIsCIIgnored(t *testing.T) {
+	target := Target{RequiredCIs: []string{"ci/*"}, IgnoredCIs: []string{"ci/flaky"}}
+
+	if !target.isCIIgnored("ci/flaky") {
+		t.Error("ci/flaky matches ignored_cis, expected ignored")
+	}
+	if target.isCIIgnored("ci/build") {
+		t.Error("ci/build matches required_cis and isn't ignored, expected not ignored")
+	}
+	if !target.isCIIgnored("deploy/build") {
+		t.Error("deploy/build doesn't match required_cis, expected ignored")
+	}
+}
+
+func TestTargetStaleAndPendingCICheckEnabledOverridesGlobal(t *testing.T) {
+	enabled, disabled := true, false
+
+	withDefault := Target{}
+	if withDefault.staleCheckEnabled() != staleCheckEnabled {
+		t.Error("unset StaleCheck should fall back to the global default")
+	}
+	if withDefault.pendingCICheckEnabled() != pendingCICheckEnabled {
+		t.Error("unset PendingCICheck should fall back to the global default")
+	}
+
+	withOverride := Target{StaleCheck: &disabled, PendingCICheck: &enabled}
+	if withOverride.staleCheckEnabled() {
+		t.Error("StaleCheck=false should override the global default")
+	}
+	if !withOverride.pendingCICheckEnabled() {
+		t.Error("PendingCICheck=true should override the global default")
+	}
+}
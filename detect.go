@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/go-github/v52/github"
+)
+
+// staleThreshold 超过这个时长没有更新或互动的 PR 会被视为 stale
+var staleThreshold = 7 * 24 * time.Hour
+
+// pendingCIWait 超过这个时长 head SHA 仍然没有任何 status/check run 会被视为 CI 未触发
+var pendingCIWait = 30 * time.Minute
+
+// staleCheckEnabled/pendingCICheckEnabled 是 stale/pending-CI 检查的全局默认开关，
+// 可以用 monitor 命令的 --stale-check/--pending-ci-check 关闭；单个 Target 可以用
+// 自己的 stale_check/pending_ci_check 字段覆盖这个默认值
+var staleCheckEnabled = true
+var pendingCICheckEnabled = true
+
+// isPRStale 判断 PR 是否超过 staleThreshold 没有更新，且近期没有评论或 review 活动
+func isPRStale(owner, repo string, pr *github.PullRequest) (bool, error) {
+	if time.Since(pr.GetUpdatedAt().Time) < staleThreshold {
+		return false, nil
+	}
+
+	cutoff := time.Now().Add(-staleThreshold)
+
+	comments, _, err := client.Issues.ListComments(context.Background(), owner, repo, pr.GetNumber(), &github.IssueListCommentsOptions{
+		Sort:        github.String("created"),
+		Direction:   github.String("desc"),
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(comments) > 0 && comments[0].GetCreatedAt().After(cutoff) {
+		return false, nil
+	}
+
+	// go-github 的 ListReviews 不支持 Sort/Direction，而且 GitHub 按提交顺序（从旧到新）
+	// 返回 review，所以要翻完所有页、取 SubmittedAt 最大的一条，不能只看第一页
+	var latestReview time.Time
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := client.PullRequests.ListReviews(context.Background(), owner, repo, pr.GetNumber(), opts)
+		if err != nil {
+			return false, err
+		}
+		for _, review := range reviews {
+			if t := review.GetSubmittedAt().Time; t.After(latestReview) {
+				latestReview = t
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if latestReview.After(cutoff) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// hasNoCIActivity 判断某个 ref 在 Statuses API 和 Checks API 下是否都完全没有结果
+func hasNoCIActivity(owner, repo, ref string) (bool, error) {
+	statuses, _, err := client.Repositories.ListStatuses(context.Background(), owner, repo, ref, nil)
+	if err != nil {
+		return false, err
+	}
+	if len(statuses) > 0 {
+		return false, nil
+	}
+
+	checkRuns, _, err := client.Checks.ListCheckRunsForRef(context.Background(), owner, repo, ref, nil)
+	if err != nil {
+		return false, err
+	}
+	if checkRuns.GetTotal() > 0 {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// isPRPendingCI 判断 PR 的 head SHA 是否在 pendingCIWait 之后仍然没有触发任何 CI
+func isPRPendingCI(owner, repo string, pr *github.PullRequest) (bool, error) {
+	if time.Since(pr.GetUpdatedAt().Time) < pendingCIWait {
+		return false, nil
+	}
+	return hasNoCIActivity(owner, repo, pr.Head.GetSHA())
+}
+
+func alertStale(n Notifier, prNumber int, prTitle string) {
+	if err := n.NotifyStale(prGroup(prNumber), prNumber, prTitle); err != nil {
+		log.Printf("Error sending stale notification: %v", err)
+	}
+}
+
+func alertPendingCI(n Notifier, prNumber int, prTitle string) {
+	if err := n.NotifyPendingCI(prGroup(prNumber), prNumber, prTitle); err != nil {
+		log.Printf("Error sending pending CI notification: %v", err)
+	}
+}
+
+// checkStaleForPR 检查单个 PR 是否 stale，如果是则发出告警
+func checkStaleForPR(owner, repo string, pr *github.PullRequest) {
+	checkStaleForPRWith(owner, repo, pr, notifier)
+}
+
+// checkStaleForPRWith 和 checkStaleForPR 逻辑一致，但告警发给调用方指定的 Notifier
+func checkStaleForPRWith(owner, repo string, pr *github.PullRequest, n Notifier) {
+	stale, err := isPRStale(owner, repo, pr)
+	if err != nil {
+		log.Printf("Error checking staleness for PR #%d: %v", *pr.Number, err)
+		return
+	}
+	if stale {
+		log.Printf("PR #%d is stale", *pr.Number)
+		alertStale(n, *pr.Number, pr.GetTitle())
+	}
+}
+
+// checkPendingCIForPR 检查单个 PR 的 CI 是否一直没有触发，如果是则发出告警
+func checkPendingCIForPR(owner, repo string, pr *github.PullRequest) {
+	checkPendingCIForPRWith(owner, repo, pr, notifier)
+}
+
+// checkPendingCIForPRWith 和 checkPendingCIForPR 逻辑一致，但告警发给调用方指定的 Notifier
+func checkPendingCIForPRWith(owner, repo string, pr *github.PullRequest, n Notifier) {
+	pending, err := isPRPendingCI(owner, repo, pr)
+	if err != nil {
+		log.Printf("Error checking pending CI for PR #%d: %v", *pr.Number, err)
+		return
+	}
+	if pending {
+		log.Printf("PR #%d has no CI activity", *pr.Number)
+		alertPendingCI(n, *pr.Number, pr.GetTitle())
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"time"
 
 	"github.com/google/go-github/v52/github"
@@ -13,7 +12,8 @@ import (
 )
 
 var client *github.Client
-var prStatusMap = make(map[int]bool) // 用于存储每个 PR 的状态
+var notifier Notifier
+var store StateStore
 
 func initClient() {
 	token := os.Getenv("GITHUB_TOKEN")
@@ -24,10 +24,17 @@ func initClient() {
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(context.Background(), ts)
+	tc.Transport = newRateLimitTransport(tc.Transport)
 	client = github.NewClient(tc)
 }
 
 func getPRs(owner, repo, creator string) ([]*github.PullRequest, error) {
+	return getPRsMatching(owner, repo, func(login string) bool { return login == creator })
+}
+
+// getPRsMatching 和 getPRs 逻辑一致，但用一个谓词函数判断作者是否符合要求，
+// 这样多仓库配置里 "authors: [\"*\"]" 之类的通配作者列表也能复用同一套分页逻辑
+func getPRsMatching(owner, repo string, matchesCreator func(login string) bool) ([]*github.PullRequest, error) {
 	since := time.Now().Add(-7 * 24 * time.Hour) // 7天内的PR
 
 	var allPRs []*github.PullRequest
@@ -59,7 +66,7 @@ func getPRs(owner, repo, creator string) ([]*github.PullRequest, error) {
 				flag = true
 				break
 			} else {
-				if pr.User.GetLogin() == creator {
+				if matchesCreator(pr.User.GetLogin()) {
 					allPRs = append(allPRs, pr)
 				}
 			}
@@ -123,6 +130,124 @@ func getCIStatusUsingStatusAPI(owner, repo, ref string, latestStatuses *map[stri
 	return *latestStatuses, nil
 }
 
+func getCIStatusUsingChecksAPI(owner, repo, ref string, latestCheckRuns *map[string]*github.CheckRun) (map[string]*github.CheckRun, error) {
+	log.Print("args: ", owner, " ", repo, " ", ref)
+
+	// 检查 client 是否为 nil
+	if client == nil {
+		log.Print("GitHub client is not initialized")
+		return nil, fmt.Errorf("GitHub client is not initialized")
+	}
+
+	checkRuns, resp, err := client.Checks.ListCheckRunsForRef(context.Background(), owner, repo, ref, nil)
+	if err != nil {
+		log.Printf("Error fetching check runs: %v", err)
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		log.Printf("Unexpected status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("Unexpected status code: %d", resp.StatusCode)
+	}
+
+	if checkRuns.GetTotal() == 0 {
+		log.Print("No check runs found")
+		return nil, fmt.Errorf("No check runs found")
+	}
+
+	log.Printf("Found %d check runs for ref %s", checkRuns.GetTotal(), ref)
+
+	for _, run := range checkRuns.CheckRuns {
+		name := run.GetName()
+		existingRun, exists := (*latestCheckRuns)[name]
+		if !exists {
+			(*latestCheckRuns)[name] = run
+		} else {
+			if checkRunUpdatedAt(run).After(checkRunUpdatedAt(existingRun)) {
+				(*latestCheckRuns)[name] = run
+			}
+		}
+	}
+
+	return *latestCheckRuns, nil
+}
+
+// checkRunUpdatedAt 返回 check run 最近一次更新的时间，优先使用完成时间
+func checkRunUpdatedAt(run *github.CheckRun) time.Time {
+	if completedAt := run.GetCompletedAt(); !completedAt.IsZero() {
+		return completedAt.Time
+	}
+	return run.GetStartedAt().Time
+}
+
+// mapCheckRunState 把 check run 的 status/conclusion 映射为和 Statuses API 相同的状态词汇
+// (pending/success/failure)，这样 checkCIForPR 可以统一处理两种来源的结果
+func mapCheckRunState(run *github.CheckRun) string {
+	if run.GetStatus() == "in_progress" || run.GetStatus() == "queued" {
+		return "pending"
+	}
+
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return "success"
+	case "failure", "cancelled", "timed_out", "action_required":
+		return "failure"
+	default:
+		return "pending"
+	}
+}
+
+type ciSource string
+
+const (
+	ciSourceStatuses ciSource = "statuses"
+	ciSourceChecks   ciSource = "checks"
+	ciSourceBoth     ciSource = "both"
+)
+
+// ciSourceMode 控制 checkCIForPR 使用哪些 CI 数据源，由 --ci-source 设置，默认两者都用
+var ciSourceMode = ciSourceBoth
+
+// parseCISource 校验 --ci-source 的取值，供 cli.go 在启动时解析 flag
+func parseCISource(s string) (ciSource, error) {
+	switch ciSource(s) {
+	case ciSourceStatuses, ciSourceChecks, ciSourceBoth:
+		return ciSource(s), nil
+	default:
+		return "", fmt.Errorf("invalid --ci-source %q: must be one of statuses, checks, both", s)
+	}
+}
+
+type ciEntry struct {
+	state     string
+	updatedAt time.Time
+}
+
+// mergeCIResults 按 context/check 名字合并 Statuses API 和 Checks API 的结果，
+// 同一个名字在两个来源都出现时，保留更新时间较新的那个
+func mergeCIResults(statuses map[string]*github.RepoStatus, checkRuns map[string]*github.CheckRun) map[string]ciEntry {
+	merged := make(map[string]ciEntry)
+
+	for ciContext, status := range statuses {
+		merged[ciContext] = ciEntry{
+			state:     status.GetState(),
+			updatedAt: status.GetUpdatedAt().Time,
+		}
+	}
+
+	for name, run := range checkRuns {
+		entry := ciEntry{
+			state:     mapCheckRunState(run),
+			updatedAt: checkRunUpdatedAt(run),
+		}
+		if existing, exists := merged[name]; !exists || entry.updatedAt.After(existing.updatedAt) {
+			merged[name] = entry
+		}
+	}
+
+	return merged
+}
+
 var notRequiredCIs = []string{
 	"PR-CI-Kunlun-R200",
 }
@@ -139,28 +264,96 @@ func checkNotRequired(CIname string) bool {
 }
 
 func checkCIForPR(owner, repo string, pr *github.PullRequest) bool {
+	return checkCIForPRWith(owner, repo, pr, checkNotRequired, notifier, store)
+}
+
+// checkCIForPRWith 和 checkCIForPR 逻辑一致，但把"这个 CI 是否可以忽略"的判断、告警发给
+// 哪个 Notifier、以及去重/退避用哪个 StateStore 都交给调用方决定，这样每个 Target 可以用
+// 自己的 required/ignored CI 列表和通知渠道，而不用依赖全局变量（并发的多个 Target 不能
+// 共享可变的全局状态）
+func checkCIForPRWith(owner, repo string, pr *github.PullRequest, isIgnored func(string) bool, n Notifier, s StateStore) bool {
 	result := true
 
-	latestStatuses := make(map[string]*github.RepoStatus)
-	latestStatusesPtr := &latestStatuses
+	var statuses map[string]*github.RepoStatus
+	var checkRuns map[string]*github.CheckRun
+	var err error
 
-	statuses, err := getCIStatusUsingStatusAPI(owner, repo, pr.Head.GetSHA(), latestStatusesPtr)
-	if err != nil {
-		log.Printf("Error getting CI status for PR #%d: %v", *pr.Number, err)
+	if ciSourceMode == ciSourceStatuses || ciSourceMode == ciSourceBoth {
+		latestStatuses := make(map[string]*github.RepoStatus)
+		statuses, err = getCIStatusUsingStatusAPI(owner, repo, pr.Head.GetSHA(), &latestStatuses)
+		if err != nil {
+			log.Printf("Error getting CI status for PR #%d: %v", *pr.Number, err)
+		}
 	}
 
-	// 这里可以添加更多的逻辑来检查 statuses 的状态
-	for context, status := range statuses {
-		if !checkNotRequired(status.GetContext()) && status.GetState() == "failure" {
-			result = false
-			log.Print("CI failed for PR: ", *pr.Number)
-			alertFailure(*pr.Number, pr.GetTitle(), context)
+	if ciSourceMode == ciSourceChecks || ciSourceMode == ciSourceBoth {
+		latestCheckRuns := make(map[string]*github.CheckRun)
+		checkRuns, err = getCIStatusUsingChecksAPI(owner, repo, pr.Head.GetSHA(), &latestCheckRuns)
+		if err != nil {
+			log.Printf("Error getting check runs for PR #%d: %v", *pr.Number, err)
+		}
+	}
+
+	headSHA := pr.Head.GetSHA()
+
+	// 合并两个来源的结果后统一判断，保证 alertFailure 对两种来源行为一致
+	for ciName, entry := range mergeCIResults(statuses, checkRuns) {
+		if isIgnored(ciName) {
+			continue
+		}
+
+		if entry.state == "pending" {
+			// 还没有结果，不更新记录的状态，避免把一次 rerun 的 "pending" 误当成
+			// 恢复，从而在 rerun 再次失败时把指数退避也一起重置掉
+			continue
+		}
+
+		now := time.Now()
+		prevState, exists, err := s.GetCIState(owner, repo, *pr.Number, headSHA, ciName)
+		if err != nil {
+			log.Printf("Error reading CI state for PR #%d/%s: %v", *pr.Number, ciName, err)
+		}
+
+		if entry.state != "failure" {
+			// 只有真的从失败恢复成功时才需要清掉失败记录，否则 shouldAlertCIFailure
+			// 读到的永远是上一次失败时写入的 "failure"，导致失败 -> 恢复 -> 再次失败
+			// 时被指数退避误判为同一轮失败；状态本来就不是失败就不必每次都写一遍
+			if exists && prevState.LastState == "failure" {
+				if err := s.PutCIState(owner, repo, *pr.Number, headSHA, ciName, ciAlertState{LastState: "success", LastAlertAt: now}); err != nil {
+					log.Printf("Error saving CI state for PR #%d/%s: %v", *pr.Number, ciName, err)
+				}
+			}
+			continue
+		}
+
+		result = false
+		log.Print("CI failed for PR: ", *pr.Number)
+
+		if !shouldAlertCIFailure(prevState, exists, now) {
+			continue
+		}
+
+		newState := ciAlertState{LastState: "failure", LastAlertAt: now, AlertCount: prevState.AlertCount + 1}
+		if !exists || prevState.LastState != "failure" {
+			newState.AlertCount = 1
+		}
+
+		alertFailure(n, *pr.Number, pr.GetTitle(), ciName, newState.AlertCount)
+
+		if err := s.PutCIState(owner, repo, *pr.Number, headSHA, ciName, newState); err != nil {
+			log.Printf("Error saving CI state for PR #%d/%s: %v", *pr.Number, ciName, err)
 		}
 	}
 	return result
 }
 
 func checkPRStatus(owner, repo string, pr *github.PullRequest) {
+	checkPRStatusWith(owner, repo, pr, notifier, store)
+}
+
+// checkPRStatusWith 和 checkPRStatus 逻辑一致，但告警发给调用方指定的 Notifier，
+// 合并状态记录在调用方指定的 StateStore 里，以便跨重启去重并留下合并时间
+func checkPRStatusWith(owner, repo string, pr *github.PullRequest, n Notifier, s StateStore) {
 	detailedPR, _, err := client.PullRequests.Get(context.Background(), owner, repo, *pr.Number)
 	if err != nil {
 		log.Printf("Error getting PR details for PR #%d: %v", *pr.Number, err)
@@ -170,56 +363,34 @@ func checkPRStatus(owner, repo string, pr *github.PullRequest) {
 	prNumber := *pr.Number
 	isMerged := detailedPR.GetMerged()
 
+	prevState, exists, err := s.GetMergeState(owner, repo, prNumber)
+	if err != nil {
+		log.Printf("Error reading merge state for PR #%d: %v", *pr.Number, err)
+	}
+
 	// 检查 PR 的状态是否已记录或是否发生变化
-	if prevState, exists := prStatusMap[prNumber]; !exists || prevState != isMerged {
-		prStatusMap[prNumber] = isMerged
+	if !exists || prevState.Merged != isMerged {
+		newState := mergeState{Merged: isMerged}
 		if isMerged {
 			log.Printf("PR #%d has been merged", prNumber)
-			alertMerge(prNumber, pr.GetTitle())
+			newState.MergedAt = time.Now()
+			alertMerge(n, prNumber, pr.GetTitle())
 		}
-	}
-}
-
-func alertFailure(prNumber int, prName, context string) {
-	title := fmt.Sprintf("PR #%d CI Failure", prNumber)
-	message := fmt.Sprintf("CI: %s\nPR: %s", context, prName)
-	group := fmt.Sprintf("PR-%d", prNumber) // 使用 PR 编号作为分组标识
-
-	// 移除旧的通知
-	removeCmd := exec.Command("terminal-notifier", "-remove", group)
-	err := removeCmd.Run()
-	if err != nil {
-		fmt.Printf("Error removing old notification: %v\n", err)
-	}
-
-	// 发送新的通知
-	for i := 0; i < 3; i++ { // 重复发送3次通知
-		cmd := exec.Command("terminal-notifier", "-title", title, "-message", message, "-timeout", "10", "-sound", "default", "-group", group)
-		err := cmd.Run()
-		if err != nil {
-			fmt.Printf("Error sending notification: %v\n", err)
+		if err := s.PutMergeState(owner, repo, prNumber, newState); err != nil {
+			log.Printf("Error saving merge state for PR #%d: %v", *pr.Number, err)
 		}
-		time.Sleep(2 * time.Second) // 每次通知之间间隔1秒
 	}
 }
 
-func alertMerge(prNumber int, prTitle string) {
-	title := fmt.Sprintf("PR #%d Merged", prNumber)
-	message := fmt.Sprintf("PR: %s", prTitle)
-	group := fmt.Sprintf("PR-%d", prNumber) // 使用 PR 编号作为分组标识
-
-	// 移除旧的通知
-	removeCmd := exec.Command("terminal-notifier", "-remove", group)
-	err := removeCmd.Run()
-	if err != nil {
-		fmt.Printf("Error removing old notification: %v\n", err)
+func alertFailure(n Notifier, prNumber int, prName, context string, alertCount int) {
+	if err := n.NotifyCIFailure(prGroup(prNumber), prNumber, prName, context, alertCount); err != nil {
+		log.Printf("Error sending CI failure notification: %v", err)
 	}
+}
 
-	// 发送新的通知
-	cmd := exec.Command("terminal-notifier", "-title", title, "-message", message, "-timeout", "10", "-sound", "default", "-group", group)
-	err = cmd.Run()
-	if err != nil {
-		fmt.Printf("Error sending notification: %v\n", err)
+func alertMerge(n Notifier, prNumber int, prTitle string) {
+	if err := n.NotifyMerged(prGroup(prNumber), prNumber, prTitle); err != nil {
+		log.Printf("Error sending merge notification: %v", err)
 	}
 }
 
@@ -238,6 +409,12 @@ func monitorPRs(owner, repo, creator string) {
 			if needtoCheckStatus {
 				checkPRStatus(owner, repo, pr) // 检查 PR 状态
 			}
+			if staleCheckEnabled {
+				checkStaleForPR(owner, repo, pr)
+			}
+			if pendingCICheckEnabled {
+				checkPendingCIForPR(owner, repo, pr)
+			}
 		}
 
 		time.Sleep(360 * time.Second)
@@ -245,9 +422,5 @@ func monitorPRs(owner, repo, creator string) {
 }
 
 func main() {
-	initClient()
-	owner := "PaddlePaddle"
-	repo := "Paddle"
-	creator := "GoldenStain"
-	monitorPRs(owner, repo, creator)
+	Execute()
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v52/github"
+)
+
+func TestMapCheckRunState(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		conclusion string
+		want       string
+	}{
+		{"in progress is pending", "in_progress", "", "pending"},
+		{"queued is pending", "queued", "", "pending"},
+		{"completed success", "completed", "success", "success"},
+		{"completed neutral", "completed", "neutral", "success"},
+		{"completed skipped", "completed", "skipped", "success"},
+		{"completed failure", "completed", "failure", "failure"},
+		{"completed cancelled", "completed", "cancelled", "failure"},
+		{"completed timed out", "completed", "timed_out", "failure"},
+		{"completed action required", "completed", "action_required", "failure"},
+		{"completed with unknown conclusion", "completed", "stale", "pending"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &github.CheckRun{
+				Status:     github.String(tt.status),
+				Conclusion: github.String(tt.conclusion),
+			}
+			if got := mapCheckRunState(run); got != tt.want {
+				t.Errorf("mapCheckRunState(status=%q, conclusion=%q) = %q, want %q", tt.status, tt.conclusion, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCISource(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    ciSource
+		wantErr bool
+	}{
+		{"statuses", "statuses", ciSourceStatuses, false},
+		{"checks", "checks", ciSourceChecks, false},
+		{"both", "both", ciSourceBoth, false},
+		{"invalid", "bogus", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCISource(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCISource(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseCISource(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeCIResultsPrefersNewerOnOverlap(t *testing.T) {
+	older := time.Now().Add(-time.Hour)
+	newer := time.Now()
+
+	statuses := map[string]*github.RepoStatus{
+		"ci/build": {State: github.String("failure"), UpdatedAt: &github.Timestamp{Time: older}},
+		"ci/lint":  {State: github.String("success"), UpdatedAt: &github.Timestamp{Time: older}},
+	}
+	checkRuns := map[string]*github.CheckRun{
+		"ci/build": {Status: github.String("completed"), Conclusion: github.String("success"), CompletedAt: &github.Timestamp{Time: newer}},
+		"ci/test":  {Status: github.String("in_progress"), CompletedAt: &github.Timestamp{}},
+	}
+
+	merged := mergeCIResults(statuses, checkRuns)
+
+	if len(merged) != 3 {
+		t.Fatalf("expected 3 merged entries, got %d: %+v", len(merged), merged)
+	}
+	if got := merged["ci/build"].state; got != "success" {
+		t.Errorf("ci/build: expected the newer check run result (success) to win, got %q", got)
+	}
+	if got := merged["ci/lint"].state; got != "success" {
+		t.Errorf("ci/lint: expected status-only entry to be kept, got %q", got)
+	}
+	if got := merged["ci/test"].state; got != "pending" {
+		t.Errorf("ci/test: expected check-run-only entry to be kept, got %q", got)
+	}
+}
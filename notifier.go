@@ -0,0 +1,483 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notifier 是所有通知渠道的统一接口。group 沿用现有的 PR-<n> 分组标识，
+// 支持原生更新/替换的渠道（如 terminal-notifier）可以用它去重已发送的通知，
+// 不支持的渠道可以借助 dedupCache 在内存里抑制重复通知。
+//
+// NotifyCIFailure 额外带上 alertCount（这是针对这个 ciContext 的第几次告警），
+// 这样 state.go 的指数退避放行的每一次告警消息内容都不同，不会被 dedupCache
+// 当成和上一次完全一样的消息误判为重复而丢弃。
+type Notifier interface {
+	NotifyCIFailure(group string, prNumber int, prTitle, ciContext string, alertCount int) error
+	NotifyMerged(group string, prNumber int, prTitle string) error
+	NotifyStale(group string, prNumber int, prTitle string) error
+	NotifyPendingCI(group string, prNumber int, prTitle string) error
+}
+
+// prGroup 返回某个 PR 对应的分组标识，所有 Notifier 实现共用同一套规则
+func prGroup(prNumber int) string {
+	return fmt.Sprintf("PR-%d", prNumber)
+}
+
+// alertKind 区分同一个 group 下的四种告警，dedupCache 按 (group, alertKind) 去重，
+// 这样一个 PR 同时 stale 又 pending CI 时，两种告警各自独立去重，互不覆盖对方的记录
+type alertKind string
+
+const (
+	alertKindCIFailure alertKind = "ci_failure"
+	alertKindMerged    alertKind = "merged"
+	alertKindStale     alertKind = "stale"
+	alertKindPendingCI alertKind = "pending_ci"
+)
+
+// dedupCache 记录每个 (分组, 告警类型) 最近一次发送的消息内容，避免对不支持原生去重的
+// 渠道重复发送完全相同的通知
+type dedupCache struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+func newDedupCache() *dedupCache {
+	return &dedupCache{entries: make(map[string]string)}
+}
+
+func (c *dedupCache) shouldSend(group string, kind alertKind, message string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := group + "|" + string(kind)
+	if last, exists := c.entries[key]; exists && last == message {
+		return false
+	}
+	c.entries[key] = message
+	return true
+}
+
+// TerminalNotifier 发送 macOS 上的 terminal-notifier 通知，是原有实现的延续
+type TerminalNotifier struct{}
+
+func NewTerminalNotifier() *TerminalNotifier {
+	return &TerminalNotifier{}
+}
+
+func (n *TerminalNotifier) send(group, title, message string, repeat int) error {
+	// 移除旧的通知
+	if err := exec.Command("terminal-notifier", "-remove", group).Run(); err != nil {
+		log.Printf("Error removing old notification: %v", err)
+	}
+
+	var lastErr error
+	for i := 0; i < repeat; i++ {
+		cmd := exec.Command("terminal-notifier", "-title", title, "-message", message, "-timeout", "10", "-sound", "default", "-group", group)
+		if err := cmd.Run(); err != nil {
+			lastErr = err
+			log.Printf("Error sending notification: %v", err)
+		}
+		if i < repeat-1 {
+			time.Sleep(2 * time.Second)
+		}
+	}
+	return lastErr
+}
+
+func (n *TerminalNotifier) NotifyCIFailure(group string, prNumber int, prTitle, ciContext string, alertCount int) error {
+	title := fmt.Sprintf("PR #%d CI Failure", prNumber)
+	message := fmt.Sprintf("CI: %s\nPR: %s", ciContext, prTitle)
+	return n.send(group, title, message, 3)
+}
+
+func (n *TerminalNotifier) NotifyMerged(group string, prNumber int, prTitle string) error {
+	title := fmt.Sprintf("PR #%d Merged", prNumber)
+	message := fmt.Sprintf("PR: %s", prTitle)
+	return n.send(group, title, message, 1)
+}
+
+func (n *TerminalNotifier) NotifyStale(group string, prNumber int, prTitle string) error {
+	title := fmt.Sprintf("PR #%d Stale", prNumber)
+	message := fmt.Sprintf("PR: %s", prTitle)
+	return n.send(group, title, message, 1)
+}
+
+func (n *TerminalNotifier) NotifyPendingCI(group string, prNumber int, prTitle string) error {
+	title := fmt.Sprintf("PR #%d Pending CI", prNumber)
+	message := fmt.Sprintf("PR: %s", prTitle)
+	return n.send(group, title, message, 1)
+}
+
+// NotifySendNotifier 发送 Linux 上基于 libnotify 的桌面通知
+type NotifySendNotifier struct {
+	dedup *dedupCache
+}
+
+func NewNotifySendNotifier() *NotifySendNotifier {
+	return &NotifySendNotifier{dedup: newDedupCache()}
+}
+
+func (n *NotifySendNotifier) send(group string, kind alertKind, title, message string) error {
+	if !n.dedup.shouldSend(group, kind, message) {
+		return nil
+	}
+	return exec.Command("notify-send", title, message).Run()
+}
+
+func (n *NotifySendNotifier) NotifyCIFailure(group string, prNumber int, prTitle, ciContext string, alertCount int) error {
+	return n.send(group, alertKindCIFailure, fmt.Sprintf("PR #%d CI Failure", prNumber), fmt.Sprintf("CI: %s\nPR: %s\nAlert: #%d", ciContext, prTitle, alertCount))
+}
+
+func (n *NotifySendNotifier) NotifyMerged(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindMerged, fmt.Sprintf("PR #%d Merged", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *NotifySendNotifier) NotifyStale(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindStale, fmt.Sprintf("PR #%d Stale", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *NotifySendNotifier) NotifyPendingCI(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindPendingCI, fmt.Sprintf("PR #%d Pending CI", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+// WindowsToastNotifier 通过 PowerShell 发送 Windows 的 Toast 通知，
+// 依赖系统自带的 BurntToast 模块（需要用户预先安装）
+type WindowsToastNotifier struct {
+	dedup *dedupCache
+}
+
+func NewWindowsToastNotifier() *WindowsToastNotifier {
+	return &WindowsToastNotifier{dedup: newDedupCache()}
+}
+
+func (n *WindowsToastNotifier) send(group string, kind alertKind, title, message string) error {
+	if !n.dedup.shouldSend(group, kind, message) {
+		return nil
+	}
+	script := fmt.Sprintf("New-BurntToastNotification -Text '%s', '%s'", strings.ReplaceAll(title, "'", "''"), strings.ReplaceAll(message, "'", "''"))
+	return exec.Command("powershell", "-NoProfile", "-Command", script).Run()
+}
+
+func (n *WindowsToastNotifier) NotifyCIFailure(group string, prNumber int, prTitle, ciContext string, alertCount int) error {
+	return n.send(group, alertKindCIFailure, fmt.Sprintf("PR #%d CI Failure", prNumber), fmt.Sprintf("CI: %s | PR: %s | Alert #%d", ciContext, prTitle, alertCount))
+}
+
+func (n *WindowsToastNotifier) NotifyMerged(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindMerged, fmt.Sprintf("PR #%d Merged", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *WindowsToastNotifier) NotifyStale(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindStale, fmt.Sprintf("PR #%d Stale", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *WindowsToastNotifier) NotifyPendingCI(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindPendingCI, fmt.Sprintf("PR #%d Pending CI", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+// webhookNotifier 是 Slack/Discord 这类增量 webhook 渠道的共同实现，
+// 两者只是请求体的字段名不同（Slack 用 text，Discord 用 content）
+type webhookNotifier struct {
+	url        string
+	bodyField  string
+	httpClient *http.Client
+	dedup      *dedupCache
+}
+
+func newWebhookNotifier(url, bodyField string) *webhookNotifier {
+	return &webhookNotifier{
+		url:        url,
+		bodyField:  bodyField,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		dedup:      newDedupCache(),
+	}
+}
+
+func (n *webhookNotifier) send(group string, kind alertKind, title, message string) error {
+	text := fmt.Sprintf("%s\n%s", title, message)
+	if !n.dedup.shouldSend(group, kind, text) {
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string]string{n.bodyField: text})
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.httpClient.Post(n.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SlackNotifier 通过 Slack incoming webhook 发送通知
+type SlackNotifier struct {
+	*webhookNotifier
+}
+
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{webhookNotifier: newWebhookNotifier(webhookURL, "text")}
+}
+
+func (n *SlackNotifier) NotifyCIFailure(group string, prNumber int, prTitle, ciContext string, alertCount int) error {
+	return n.send(group, alertKindCIFailure, fmt.Sprintf("PR #%d CI Failure", prNumber), fmt.Sprintf("CI: %s\nPR: %s\nAlert: #%d", ciContext, prTitle, alertCount))
+}
+
+func (n *SlackNotifier) NotifyMerged(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindMerged, fmt.Sprintf("PR #%d Merged", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *SlackNotifier) NotifyStale(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindStale, fmt.Sprintf("PR #%d Stale", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *SlackNotifier) NotifyPendingCI(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindPendingCI, fmt.Sprintf("PR #%d Pending CI", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+// DiscordNotifier 通过 Discord webhook 发送通知
+type DiscordNotifier struct {
+	*webhookNotifier
+}
+
+func NewDiscordNotifier(webhookURL string) *DiscordNotifier {
+	return &DiscordNotifier{webhookNotifier: newWebhookNotifier(webhookURL, "content")}
+}
+
+func (n *DiscordNotifier) NotifyCIFailure(group string, prNumber int, prTitle, ciContext string, alertCount int) error {
+	return n.send(group, alertKindCIFailure, fmt.Sprintf("PR #%d CI Failure", prNumber), fmt.Sprintf("CI: %s\nPR: %s\nAlert: #%d", ciContext, prTitle, alertCount))
+}
+
+func (n *DiscordNotifier) NotifyMerged(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindMerged, fmt.Sprintf("PR #%d Merged", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *DiscordNotifier) NotifyStale(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindStale, fmt.Sprintf("PR #%d Stale", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *DiscordNotifier) NotifyPendingCI(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindPendingCI, fmt.Sprintf("PR #%d Pending CI", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+// KeybaseNotifier 通过 keybase chat send 把通知发到指定的 Keybase 会话
+type KeybaseNotifier struct {
+	channel string
+	dedup   *dedupCache
+}
+
+func NewKeybaseNotifier(channel string) *KeybaseNotifier {
+	return &KeybaseNotifier{channel: channel, dedup: newDedupCache()}
+}
+
+func (n *KeybaseNotifier) send(group string, kind alertKind, title, message string) error {
+	text := fmt.Sprintf("%s\n%s", title, message)
+	if !n.dedup.shouldSend(group, kind, text) {
+		return nil
+	}
+	return exec.Command("keybase", "chat", "send", n.channel, text).Run()
+}
+
+func (n *KeybaseNotifier) NotifyCIFailure(group string, prNumber int, prTitle, ciContext string, alertCount int) error {
+	return n.send(group, alertKindCIFailure, fmt.Sprintf("PR #%d CI Failure", prNumber), fmt.Sprintf("CI: %s\nPR: %s\nAlert: #%d", ciContext, prTitle, alertCount))
+}
+
+func (n *KeybaseNotifier) NotifyMerged(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindMerged, fmt.Sprintf("PR #%d Merged", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *KeybaseNotifier) NotifyStale(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindStale, fmt.Sprintf("PR #%d Stale", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *KeybaseNotifier) NotifyPendingCI(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindPendingCI, fmt.Sprintf("PR #%d Pending CI", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+// EmailNotifier 通过 SMTP 发送邮件通知
+type EmailNotifier struct {
+	smtpAddr string
+	auth     smtp.Auth
+	from     string
+	to       []string
+	dedup    *dedupCache
+}
+
+func NewEmailNotifier(host string, port int, username, password, from string, to []string) *EmailNotifier {
+	return &EmailNotifier{
+		smtpAddr: host + ":" + strconv.Itoa(port),
+		auth:     smtp.PlainAuth("", username, password, host),
+		from:     from,
+		to:       to,
+		dedup:    newDedupCache(),
+	}
+}
+
+func (n *EmailNotifier) send(group string, kind alertKind, title, message string) error {
+	if !n.dedup.shouldSend(group, kind, message) {
+		return nil
+	}
+	body := fmt.Sprintf("Subject: %s\r\n\r\n%s\r\n", title, message)
+	return smtp.SendMail(n.smtpAddr, n.auth, n.from, n.to, []byte(body))
+}
+
+func (n *EmailNotifier) NotifyCIFailure(group string, prNumber int, prTitle, ciContext string, alertCount int) error {
+	return n.send(group, alertKindCIFailure, fmt.Sprintf("PR #%d CI Failure", prNumber), fmt.Sprintf("CI: %s\nPR: %s\nAlert: #%d", ciContext, prTitle, alertCount))
+}
+
+func (n *EmailNotifier) NotifyMerged(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindMerged, fmt.Sprintf("PR #%d Merged", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *EmailNotifier) NotifyStale(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindStale, fmt.Sprintf("PR #%d Stale", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+func (n *EmailNotifier) NotifyPendingCI(group string, prNumber int, prTitle string) error {
+	return n.send(group, alertKindPendingCI, fmt.Sprintf("PR #%d Pending CI", prNumber), fmt.Sprintf("PR: %s", prTitle))
+}
+
+// MultiNotifier 把一条告警同时投递给所有启用的 Notifier，单个渠道失败不影响其它渠道
+type MultiNotifier struct {
+	notifiers []Notifier
+}
+
+func NewMultiNotifier(notifiers ...Notifier) *MultiNotifier {
+	return &MultiNotifier{notifiers: notifiers}
+}
+
+func (m *MultiNotifier) NotifyCIFailure(group string, prNumber int, prTitle, ciContext string, alertCount int) error {
+	for _, n := range m.notifiers {
+		if err := n.NotifyCIFailure(group, prNumber, prTitle, ciContext, alertCount); err != nil {
+			log.Printf("Error sending CI failure notification via %T: %v", n, err)
+		}
+	}
+	return nil
+}
+
+func (m *MultiNotifier) NotifyMerged(group string, prNumber int, prTitle string) error {
+	for _, n := range m.notifiers {
+		if err := n.NotifyMerged(group, prNumber, prTitle); err != nil {
+			log.Printf("Error sending merge notification via %T: %v", n, err)
+		}
+	}
+	return nil
+}
+
+func (m *MultiNotifier) NotifyStale(group string, prNumber int, prTitle string) error {
+	for _, n := range m.notifiers {
+		if err := n.NotifyStale(group, prNumber, prTitle); err != nil {
+			log.Printf("Error sending stale notification via %T: %v", n, err)
+		}
+	}
+	return nil
+}
+
+func (m *MultiNotifier) NotifyPendingCI(group string, prNumber int, prTitle string) error {
+	for _, n := range m.notifiers {
+		if err := n.NotifyPendingCI(group, prNumber, prTitle); err != nil {
+			log.Printf("Error sending pending CI notification via %T: %v", n, err)
+		}
+	}
+	return nil
+}
+
+// buildNotifiers 按 NOTIFIERS 环境变量（逗号分隔，默认 "terminal"）启用对应的渠道，
+// 缺少必要配置的渠道会被跳过并记录日志，而不是让整个程序退出
+func buildNotifiers() []Notifier {
+	names := os.Getenv("NOTIFIERS")
+	if names == "" {
+		names = "terminal"
+	}
+	return buildNotifiersFromNames(strings.Split(names, ","))
+}
+
+// buildNotifiersFromNames 按名字列表实例化 Notifier，供全局 NOTIFIERS 环境变量
+// 和 Target 级别的 notifiers 配置共用
+func buildNotifiersFromNames(names []string) []Notifier {
+	var notifiers []Notifier
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case "terminal":
+			notifiers = append(notifiers, NewTerminalNotifier())
+		case "notify-send":
+			notifiers = append(notifiers, NewNotifySendNotifier())
+		case "windows-toast":
+			notifiers = append(notifiers, NewWindowsToastNotifier())
+		case "slack":
+			url := os.Getenv("SLACK_WEBHOOK_URL")
+			if url == "" {
+				log.Print("SLACK_WEBHOOK_URL not set, skipping slack notifier")
+				continue
+			}
+			notifiers = append(notifiers, NewSlackNotifier(url))
+		case "discord":
+			url := os.Getenv("DISCORD_WEBHOOK_URL")
+			if url == "" {
+				log.Print("DISCORD_WEBHOOK_URL not set, skipping discord notifier")
+				continue
+			}
+			notifiers = append(notifiers, NewDiscordNotifier(url))
+		case "keybase":
+			channel := os.Getenv("KEYBASE_CHANNEL")
+			if channel == "" {
+				log.Print("KEYBASE_CHANNEL not set, skipping keybase notifier")
+				continue
+			}
+			notifiers = append(notifiers, NewKeybaseNotifier(channel))
+		case "email":
+			notifier, err := buildEmailNotifierFromEnv()
+			if err != nil {
+				log.Printf("Error configuring email notifier: %v", err)
+				continue
+			}
+			notifiers = append(notifiers, notifier)
+		case "":
+			// 忽略空白项（例如逗号两边多余的空格）
+		default:
+			log.Printf("Unknown notifier %q, skipping", name)
+		}
+	}
+
+	return notifiers
+}
+
+func buildEmailNotifierFromEnv() (*EmailNotifier, error) {
+	host := os.Getenv("SMTP_HOST")
+	portStr := os.Getenv("SMTP_PORT")
+	username := os.Getenv("SMTP_USERNAME")
+	password := os.Getenv("SMTP_PASSWORD")
+	from := os.Getenv("SMTP_FROM")
+	to := os.Getenv("SMTP_TO")
+
+	if host == "" || from == "" || to == "" {
+		return nil, fmt.Errorf("SMTP_HOST, SMTP_FROM and SMTP_TO are required")
+	}
+
+	port := 587
+	if portStr != "" {
+		parsed, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMTP_PORT %q: %w", portStr, err)
+		}
+		port = parsed
+	}
+
+	return NewEmailNotifier(host, port, username, password, from, strings.Split(to, ",")), nil
+}
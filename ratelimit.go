@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitWatermark 是 X-RateLimit-Remaining 的安全水位，低于这个值就直接等到
+// X-RateLimit-Reset，而不是继续把配额打光导致后面的请求被拒绝
+const rateLimitWatermark = 50
+
+// maxRetryAfterAttempts 限制 403/secondary rate limit 响应触发的重试次数，避免无限重试
+const maxRetryAfterAttempts = 5
+
+// cachedResponse 保存一次成功响应的 ETag/Last-Modified 和响应体，
+// 下次对同一个 URL 发请求时可以带上条件请求头，命中 304 时直接复用
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	statusCode   int
+	header       http.Header
+	body         []byte
+}
+
+// rateLimitTransport 是一个 http.RoundTripper，包装在 oauth2 的 Transport 外层，
+// 负责 ETag 缓存、条件请求、限速水位等待和 Retry-After 退避
+type rateLimitTransport struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	cache     map[string]*cachedResponse
+	remaining int
+	reset     time.Time
+}
+
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	return &rateLimitTransport{
+		base:  base,
+		cache: make(map[string]*cachedResponse),
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.waitForRateLimit()
+
+	key := req.URL.String()
+	isGet := req.Method == http.MethodGet || req.Method == ""
+
+	var cached *cachedResponse
+	if isGet {
+		t.mu.Lock()
+		cached = t.cache[key]
+		t.mu.Unlock()
+		if cached != nil {
+			if cached.etag != "" {
+				req.Header.Set("If-None-Match", cached.etag)
+			}
+			if cached.lastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.lastModified)
+			}
+		}
+	}
+
+	resp, err := t.roundTripWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.recordRateLimit(resp)
+
+	if isGet && cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return cached.toResponse(req), nil
+	}
+
+	if isGet && resp.StatusCode == http.StatusOK {
+		t.maybeCacheResponse(key, resp)
+	}
+
+	return resp, nil
+}
+
+// roundTripWithRetry 发出请求，遇到 403/secondary rate limit 并带有 Retry-After 时
+// 做带抖动的指数退避重试
+func (t *rateLimitTransport) roundTripWithRetry(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+
+	for attempt := 0; attempt <= maxRetryAfterAttempts; attempt++ {
+		resp, err = t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		retryAfter, ok := parseRetryAfter(resp)
+		if !ok || resp.StatusCode != http.StatusForbidden {
+			return resp, nil
+		}
+
+		resp.Body.Close()
+
+		wait := jitteredBackoff(retryAfter, attempt)
+		log.Printf("Hit GitHub rate limit, retrying %s after %s (attempt %d)", req.URL, wait, attempt+1)
+		time.Sleep(wait)
+	}
+
+	return resp, err
+}
+
+// waitForRateLimit 如果上一次响应显示配额已经逼近水位，就一直睡到 X-RateLimit-Reset
+func (t *rateLimitTransport) waitForRateLimit() {
+	t.mu.Lock()
+	remaining, reset := t.remaining, t.reset
+	t.mu.Unlock()
+
+	if remaining > rateLimitWatermark || reset.IsZero() {
+		return
+	}
+
+	if wait := time.Until(reset); wait > 0 {
+		log.Printf("GitHub rate limit remaining=%d below watermark, sleeping %s until reset", remaining, wait)
+		time.Sleep(wait)
+	}
+}
+
+func (t *rateLimitTransport) recordRateLimit(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	resetUnix, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.remaining = remaining
+	t.reset = time.Unix(resetUnix, 0)
+	t.mu.Unlock()
+}
+
+func (t *rateLimitTransport) maybeCacheResponse(key string, resp *http.Response) {
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	t.cache[key] = &cachedResponse{
+		etag:         etag,
+		lastModified: lastModified,
+		statusCode:   resp.StatusCode,
+		header:       resp.Header.Clone(),
+		body:         body,
+	}
+	t.mu.Unlock()
+}
+
+// toResponse 把缓存的响应还原成一个新的 http.Response，供 304 命中时使用
+func (c *cachedResponse) toResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(c.statusCode),
+		StatusCode:    c.statusCode,
+		Header:        c.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
+
+// parseRetryAfter 读取 Retry-After 头（秒数），没有这个头就说明不是需要退避的限速响应
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// jitteredBackoff 在 Retry-After 建议的等待时间基础上按尝试次数指数增长，并加入抖动，
+// 避免多个实例在同一时刻集体重试
+func jitteredBackoff(base time.Duration, attempt int) time.Duration {
+	backoff := base
+	for i := 0; i < attempt; i++ {
+		backoff *= 2
+	}
+	jitter := time.Duration(rand.Int63n(int64(base) + 1))
+	return backoff + jitter
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		wantOK     bool
+		want       time.Duration
+	}{
+		{"missing header", "", false, 0},
+		{"valid seconds", "30", true, 30 * time.Second},
+		{"not a number", "later", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+
+			got, ok := parseRetryAfter(resp)
+			if ok != tt.wantOK {
+				t.Fatalf("parseRetryAfter() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJitteredBackoffGrowsExponentiallyAndAddsJitter(t *testing.T) {
+	base := 10 * time.Second
+
+	for attempt := 0; attempt < 4; attempt++ {
+		minWait := base << attempt
+		maxWait := minWait + base
+
+		for i := 0; i < 20; i++ {
+			wait := jitteredBackoff(base, attempt)
+			if wait < minWait || wait > maxWait {
+				t.Fatalf("jitteredBackoff(%v, %d) = %v, want in [%v, %v]", base, attempt, wait, minWait, maxWait)
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ciStatesBucket/mergesBucket 是 BoltDB 里存放两类状态的 bucket 名字
+var (
+	ciStatesBucket = []byte("ci_states")
+	mergesBucket   = []byte("merges")
+)
+
+// ciAlertState 记录某个 (owner, repo, prNumber, headSHA, ciContext) 组合最近一次看到的
+// CI 状态，以及上一次告警的时间和已经告警过多少次，用来做去重和指数退避
+type ciAlertState struct {
+	LastState   string    `json:"last_state"`
+	LastAlertAt time.Time `json:"last_alert_at"`
+	AlertCount  int       `json:"alert_count"`
+}
+
+// mergeState 记录一个 PR 的合并状态，MergedAt 为空表示还没有合并（或者合并时间未知）
+type mergeState struct {
+	Merged   bool      `json:"merged"`
+	MergedAt time.Time `json:"merged_at"`
+}
+
+// StateStore 持久化 PR/CI 的历史状态，使得重启程序不会对已经告警过的失败和合并重新告警
+type StateStore interface {
+	GetCIState(owner, repo string, prNumber int, headSHA, ciContext string) (ciAlertState, bool, error)
+	PutCIState(owner, repo string, prNumber int, headSHA, ciContext string, state ciAlertState) error
+	GetMergeState(owner, repo string, prNumber int) (mergeState, bool, error)
+	PutMergeState(owner, repo string, prNumber int, state mergeState) error
+	Close() error
+}
+
+func ciStateKey(owner, repo string, prNumber int, headSHA, ciContext string) string {
+	return fmt.Sprintf("%s/%s/%d/%s/%s", owner, repo, prNumber, headSHA, ciContext)
+}
+
+func mergeStateKey(owner, repo string, prNumber int) string {
+	return fmt.Sprintf("%s/%s/%d", owner, repo, prNumber)
+}
+
+// NewStateStore 按传入的路径选择状态后端：空字符串使用进程内的 MemoryStateStore
+// （和老版本的 prStatusMap 行为等价，适合测试和一次性运行），否则打开一个 BoltDB 文件
+func NewStateStore(dbPath string) (StateStore, error) {
+	if dbPath == "" {
+		return NewMemoryStateStore(), nil
+	}
+	return newBoltStateStore(dbPath)
+}
+
+// MemoryStateStore 是 StateStore 的纯内存实现。RunTargets 会给每个 Target 起一个
+// goroutine，它们可能共享同一个 MemoryStateStore（没有配置 --state-db 时），所以这里
+// 必须加锁保护两个 map，否则并发的 CI 检查会触发 "concurrent map writes" panic
+type MemoryStateStore struct {
+	mu       sync.Mutex
+	ciStates map[string]ciAlertState
+	merges   map[string]mergeState
+}
+
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		ciStates: make(map[string]ciAlertState),
+		merges:   make(map[string]mergeState),
+	}
+}
+
+func (s *MemoryStateStore) GetCIState(owner, repo string, prNumber int, headSHA, ciContext string) (ciAlertState, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, exists := s.ciStates[ciStateKey(owner, repo, prNumber, headSHA, ciContext)]
+	return state, exists, nil
+}
+
+func (s *MemoryStateStore) PutCIState(owner, repo string, prNumber int, headSHA, ciContext string, state ciAlertState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ciStates[ciStateKey(owner, repo, prNumber, headSHA, ciContext)] = state
+	return nil
+}
+
+func (s *MemoryStateStore) GetMergeState(owner, repo string, prNumber int) (mergeState, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, exists := s.merges[mergeStateKey(owner, repo, prNumber)]
+	return state, exists, nil
+}
+
+func (s *MemoryStateStore) PutMergeState(owner, repo string, prNumber int, state mergeState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.merges[mergeStateKey(owner, repo, prNumber)] = state
+	return nil
+}
+
+func (s *MemoryStateStore) Close() error {
+	return nil
+}
+
+// BoltStateStore 是 StateStore 的默认持久化实现，底层用一个 BoltDB 文件保存状态
+type BoltStateStore struct {
+	db *bolt.DB
+}
+
+func newBoltStateStore(dbPath string) (*BoltStateStore, error) {
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(ciStatesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(mergesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStateStore{db: db}, nil
+}
+
+func (s *BoltStateStore) GetCIState(owner, repo string, prNumber int, headSHA, ciContext string) (ciAlertState, bool, error) {
+	var state ciAlertState
+	var exists bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(ciStatesBucket).Get([]byte(ciStateKey(owner, repo, prNumber, headSHA, ciContext)))
+		if value == nil {
+			return nil
+		}
+		exists = true
+		return json.Unmarshal(value, &state)
+	})
+
+	return state, exists, err
+}
+
+func (s *BoltStateStore) PutCIState(owner, repo string, prNumber int, headSHA, ciContext string, state ciAlertState) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(ciStatesBucket).Put([]byte(ciStateKey(owner, repo, prNumber, headSHA, ciContext)), value)
+	})
+}
+
+func (s *BoltStateStore) GetMergeState(owner, repo string, prNumber int) (mergeState, bool, error) {
+	var state mergeState
+	var exists bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(mergesBucket).Get([]byte(mergeStateKey(owner, repo, prNumber)))
+		if value == nil {
+			return nil
+		}
+		exists = true
+		return json.Unmarshal(value, &state)
+	})
+
+	return state, exists, err
+}
+
+func (s *BoltStateStore) PutMergeState(owner, repo string, prNumber int, state mergeState) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(mergesBucket).Put([]byte(mergeStateKey(owner, repo, prNumber)), value)
+	})
+}
+
+func (s *BoltStateStore) Close() error {
+	return s.db.Close()
+}
+
+// backoffBase/backoffMax 控制重复失败的 CI 再次告警之前要等待多久：
+// 等待时间按 2^AlertCount * backoffBase 增长，直到 backoffMax 封顶
+const (
+	backoffBase = 5 * time.Minute
+	backoffMax  = 6 * time.Hour
+)
+
+// nextAlertBackoff 返回已经告警 alertCount 次之后，下一次告警至少要再等待多久
+func nextAlertBackoff(alertCount int) time.Duration {
+	backoff := backoffBase
+	for i := 0; i < alertCount && backoff < backoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > backoffMax {
+		backoff = backoffMax
+	}
+	return backoff
+}
+
+// shouldAlertCIFailure 根据之前存下来的状态决定这次失败是否需要告警：
+// 状态从非失败变成失败时立即告警；持续失败则按指数退避节流重复告警
+func shouldAlertCIFailure(prev ciAlertState, exists bool, now time.Time) bool {
+	if !exists || prev.LastState != "failure" {
+		return true
+	}
+	return now.Sub(prev.LastAlertAt) >= nextAlertBackoff(prev.AlertCount)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextAlertBackoff(t *testing.T) {
+	tests := []struct {
+		alertCount int
+		want       time.Duration
+	}{
+		{0, backoffBase},
+		{1, 2 * backoffBase},
+		{2, 4 * backoffBase},
+		{3, 8 * backoffBase},
+	}
+
+	for _, tt := range tests {
+		if got := nextAlertBackoff(tt.alertCount); got != tt.want {
+			t.Errorf("nextAlertBackoff(%d) = %v, want %v", tt.alertCount, got, tt.want)
+		}
+	}
+}
+
+func TestNextAlertBackoffCapsAtMax(t *testing.T) {
+	if got := nextAlertBackoff(20); got != backoffMax {
+		t.Errorf("nextAlertBackoff(20) = %v, want capped at %v", got, backoffMax)
+	}
+}
+
+func TestShouldAlertCIFailureFirstSighting(t *testing.T) {
+	now := time.Now()
+	if !shouldAlertCIFailure(ciAlertState{}, false, now) {
+		t.Error("expected first sighting of a failure to alert immediately")
+	}
+}
+
+func TestShouldAlertCIFailureTransitionFromNonFailure(t *testing.T) {
+	now := time.Now()
+	prev := ciAlertState{LastState: "success", LastAlertAt: now.Add(-time.Second)}
+	if !shouldAlertCIFailure(prev, true, now) {
+		t.Error("expected transition from non-failure to alert immediately, regardless of backoff")
+	}
+}
+
+func TestShouldAlertCIFailureRespectsBackoff(t *testing.T) {
+	now := time.Now()
+	prev := ciAlertState{LastState: "failure", LastAlertAt: now, AlertCount: 0}
+
+	if shouldAlertCIFailure(prev, true, now.Add(backoffBase-time.Minute)) {
+		t.Error("expected no alert before the backoff window elapses")
+	}
+	if !shouldAlertCIFailure(prev, true, now.Add(backoffBase+time.Minute)) {
+		t.Error("expected an alert once the backoff window elapses")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/google/go-github/v52/github"
+)
+
+// webhookServer 把 GitHub 发来的 pull_request/status/check_run/check_suite 事件
+// 直接派发给现有的 checkCIForPRWith/checkPRStatusWith 流程，跳过轮询的等待时间
+type webhookServer struct {
+	secret  []byte
+	targets []Target
+}
+
+// findTarget 按 owner/repo 精确匹配找到事件对应的 Target 配置
+func (s *webhookServer) findTarget(owner, repo string) (Target, bool) {
+	for _, t := range s.targets {
+		if t.Owner == owner && t.Repo == repo {
+			return t, true
+		}
+	}
+	return Target{}, false
+}
+
+func (s *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	payload, err := github.ValidatePayload(r, s.secret)
+	if err != nil {
+		log.Printf("Rejecting webhook delivery: %v", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event, err := github.ParseWebHook(github.WebHookType(r), payload)
+	if err != nil {
+		log.Printf("Error parsing webhook payload: %v", err)
+		http.Error(w, "unrecognized event", http.StatusBadRequest)
+		return
+	}
+
+	switch e := event.(type) {
+	case *github.PullRequestEvent:
+		s.handlePullRequestEvent(e)
+	case *github.StatusEvent:
+		s.handleHeadSHAEvent(e.GetRepo(), e.GetSHA())
+	case *github.CheckRunEvent:
+		s.handleHeadSHAEvent(e.GetRepo(), e.GetCheckRun().GetHeadSHA())
+	case *github.CheckSuiteEvent:
+		s.handleHeadSHAEvent(e.GetRepo(), e.GetCheckSuite().GetHeadSHA())
+	default:
+		log.Printf("Ignoring unsupported webhook event %s", github.WebHookType(r))
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *webhookServer) handlePullRequestEvent(e *github.PullRequestEvent) {
+	owner := e.GetRepo().GetOwner().GetLogin()
+	repo := e.GetRepo().GetName()
+
+	target, ok := s.findTarget(owner, repo)
+	if !ok {
+		return
+	}
+	if !target.matchesCreator(e.GetPullRequest().GetUser().GetLogin()) {
+		return
+	}
+
+	n := resolveNotifier(target)
+	checkPRStatusWith(owner, repo, e.GetPullRequest(), n, store)
+}
+
+// handleHeadSHAEvent 处理只带有 head SHA 的事件（status/check_run/check_suite），
+// 反查出这个 SHA 对应的 PR 后复用和轮询一致的 CI 检查逻辑
+func (s *webhookServer) handleHeadSHAEvent(repo *github.Repository, headSHA string) {
+	if repo == nil || headSHA == "" {
+		return
+	}
+
+	owner := repo.GetOwner().GetLogin()
+	repoName := repo.GetName()
+
+	target, ok := s.findTarget(owner, repoName)
+	if !ok {
+		return
+	}
+
+	prs, _, err := client.PullRequests.ListPullRequestsWithCommit(context.Background(), owner, repoName, headSHA, nil)
+	if err != nil {
+		log.Printf("Error resolving PRs for %s/%s@%s: %v", owner, repoName, headSHA, err)
+		return
+	}
+
+	n := resolveNotifier(target)
+	for _, pr := range prs {
+		if !target.matchesCreator(pr.GetUser().GetLogin()) {
+			continue
+		}
+		needToCheckStatus := checkCIForPRWith(owner, repoName, pr, target.isCIIgnored, n, store)
+		if needToCheckStatus {
+			checkPRStatusWith(owner, repoName, pr, n, store)
+		}
+	}
+}
+
+// ServeWebhooks 启动 HTTP 服务器接收 webhook 事件，并为没有配置 webhook 的 Target
+// 继续走轮询兜底；两条路径共用同一个 StateStore，所以不会重复告警
+func ServeWebhooks(ctx context.Context, addr, secret string, cfg *Config) error {
+	for _, target := range cfg.Targets {
+		if !target.Webhook {
+			go monitorTarget(ctx, target)
+		}
+	}
+
+	server := &webhookServer{secret: []byte(secret), targets: cfg.Targets}
+	mux := http.NewServeMux()
+	mux.Handle("/webhook", server)
+
+	httpServer := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+
+	log.Printf("Listening for GitHub webhooks on %s", addr)
+	err := httpServer.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// RunTargets 为配置文件里的每个 Target 启动一个独立的 goroutine，
+// 它们共享同一个已经初始化好的 *github.Client
+func RunTargets(ctx context.Context, cfg *Config) {
+	for _, target := range cfg.Targets {
+		go monitorTarget(ctx, target)
+	}
+	<-ctx.Done()
+}
+
+// monitorTarget 是 monitorPRs 的多仓库版本：按 Target 自己的轮询间隔、作者列表、
+// required/ignored CI 和通知渠道去检查 PR，直到 ctx 被取消
+func monitorTarget(ctx context.Context, target Target) {
+	n := resolveNotifier(target)
+
+	for {
+		prs, err := getPRsMatching(target.Owner, target.Repo, target.matchesCreator)
+		if err != nil {
+			log.Printf("Error fetching PRs for %s/%s: %v", target.Owner, target.Repo, err)
+		} else {
+			for _, pr := range prs {
+				log.Printf("Checking %s/%s PR #%d", target.Owner, target.Repo, *pr.Number)
+				needToCheckStatus := checkCIForPRWith(target.Owner, target.Repo, pr, target.isCIIgnored, n, store)
+				if needToCheckStatus {
+					checkPRStatusWith(target.Owner, target.Repo, pr, n, store)
+				}
+				if target.staleCheckEnabled() {
+					checkStaleForPRWith(target.Owner, target.Repo, pr, n)
+				}
+				if target.pendingCICheckEnabled() {
+					checkPendingCIForPRWith(target.Owner, target.Repo, pr, n)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(target.PollInterval):
+		}
+	}
+}
+
+// resolveNotifier 返回 Target 应当使用的 Notifier：配置了自己的 notifiers 列表就用那份，
+// 否则回退到全局 NOTIFIERS 配置
+func resolveNotifier(target Target) Notifier {
+	if len(target.Notifiers) == 0 {
+		return notifier
+	}
+	return NewMultiNotifier(buildNotifiersFromNames(target.Notifiers)...)
+}
+
+// RunWithHotReload 加载配置文件并启动所有 Target，收到 SIGHUP 时重新读取配置文件，
+// 取消旧的 Target goroutine 并用新配置重新启动
+func RunWithHotReload(configPath string) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	for {
+		cfg, err := LoadConfig(configPath)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go RunTargets(ctx, cfg)
+
+		<-sigCh
+		log.Print("Received SIGHUP, reloading config")
+		cancel()
+	}
+}